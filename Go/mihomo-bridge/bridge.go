@@ -139,6 +139,33 @@ func SetTUNFd(fd int32) error {
 	return nil
 }
 
+// currentTunFd returns the TUN file descriptor set by SetTUNFd, guarded by mu.
+func currentTunFd() int32 {
+	mu.Lock()
+	defer mu.Unlock()
+	return tunFdGlobal
+}
+
+// applyIOSTunOverrides mutates cfg to match the runtime state StartProxy
+// establishes: TUN reads/writes through the NEPacketTunnelProvider file
+// descriptor instead of a device Mihomo creates itself, auto-routing and
+// interface auto-detection are disabled (iOS owns routing), IPv6 is off, and
+// process matching is disabled (unsupported on iOS). config.yaml on disk
+// never contains these fields, so any code that calls executor.ApplyConfig
+// while the tunnel is already running must apply this first, or the reload
+// will wipe out the live TUN fd and kill routing inside the NE.
+func applyIOSTunOverrides(cfg *config.Config, tunFd int32) {
+	cfg.General.FindProcessMode = process.FindProcessMode(process.FindProcessOff)
+
+	if tunFd >= 0 {
+		cfg.General.Tun.Enable = true
+		cfg.General.Tun.FileDescriptor = int(tunFd)
+		cfg.General.Tun.AutoRoute = false
+		cfg.General.Tun.AutoDetectInterface = false
+		cfg.General.Tun.Inet6Address = nil
+	}
+}
+
 // StartProxy starts the Mihomo proxy engine with the configuration in the home directory.
 func StartProxy() error {
 	mu.Lock()
@@ -167,17 +194,8 @@ func StartProxy() error {
 	runtime.GC()
 	debug.FreeOSMemory()
 
-	// Disable process finding on iOS (not supported)
-	cfg.General.FindProcessMode = process.FindProcessMode(process.FindProcessOff)
-
-	// Inject TUN file descriptor from iOS if available.
-	// Mihomo's sing-tun uses this fd instead of creating its own TUN device.
+	applyIOSTunOverrides(cfg, tunFdGlobal)
 	if tunFdGlobal >= 0 {
-		cfg.General.Tun.Enable = true
-		cfg.General.Tun.FileDescriptor = int(tunFdGlobal)
-		cfg.General.Tun.AutoRoute = false
-		cfg.General.Tun.AutoDetectInterface = false
-		cfg.General.Tun.Inet6Address = nil
 		bridgeLog("TUN: enable=true fd=%d ipv6=disabled", tunFdGlobal)
 	} else {
 		bridgeLog("WARNING: tunFd=%d, TUN will NOT be enabled", tunFdGlobal)
@@ -222,21 +240,11 @@ func StartWithExternalController(addr, secret string) error {
 	runtime.GC()
 	debug.FreeOSMemory()
 
-	// Disable process finding on iOS (not supported)
-	cfg.General.FindProcessMode = process.FindProcessMode(process.FindProcessOff)
-
 	// Override external controller settings
 	cfg.Controller.ExternalController = addr
 	cfg.Controller.Secret = secret
 
-	// Inject TUN fd
-	if tunFdGlobal >= 0 {
-		cfg.General.Tun.Enable = true
-		cfg.General.Tun.FileDescriptor = int(tunFdGlobal)
-		cfg.General.Tun.AutoRoute = false
-		cfg.General.Tun.AutoDetectInterface = false
-		cfg.General.Tun.Inet6Address = nil
-	}
+	applyIOSTunOverrides(cfg, tunFdGlobal)
 
 	// hub.ApplyConfig starts both the external controller (REST API) and
 	// applies the proxy/TUN/DNS config via executor.ApplyConfig internally.
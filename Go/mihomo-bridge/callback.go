@@ -0,0 +1,143 @@
+// Copyright (c) 2026 Max Lv <max.c.lv@gmail.com>
+//
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+
+package bridge
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/metacubex/mihomo/log"
+	"github.com/metacubex/mihomo/tunnel"
+	"github.com/metacubex/mihomo/tunnel/statistic"
+)
+
+// BridgeCallback streams live engine events to the iOS host, so the UI can
+// render speed graphs and a log view without starting the REST controller
+// (StartWithExternalController) or tailing a log file (SetLogFile).
+type BridgeCallback interface {
+	OnLog(level, payload string)
+	OnTraffic(up, down int64)
+	OnMemory(inuse int64)
+	OnProxyChange(group, now string)
+}
+
+// callbackEventBuffer bounds how many pending log events we hold for a slow
+// Swift consumer; the NE has a ~15MB memory budget, so a blocked callback
+// must never apply backpressure onto the log subscriber.
+const callbackEventBuffer = 64
+
+type logEvent struct {
+	level   string
+	payload string
+}
+
+var (
+	callbackMu   sync.Mutex
+	callbackStop chan struct{}
+)
+
+// RegisterCallback starts streaming log, traffic, memory, and proxy-selection
+// events to cb. Passing nil stops the previous registration, if any.
+func RegisterCallback(cb BridgeCallback) {
+	callbackMu.Lock()
+	defer callbackMu.Unlock()
+
+	if callbackStop != nil {
+		close(callbackStop)
+		callbackStop = nil
+	}
+	if cb == nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	callbackStop = stop
+
+	logCh := make(chan logEvent, callbackEventBuffer)
+	sub := log.Subscribe()
+	go pumpLogEvents(sub, logCh, stop)
+	go deliverLogEvents(cb, logCh, stop)
+	go pumpTrafficAndProxyEvents(cb, stop)
+
+	bridgeLog("Callback registered")
+}
+
+func pumpLogEvents(sub <-chan log.Event, logCh chan<- logEvent, stop <-chan struct{}) {
+	// log.Subscribe() registers us with mihomo's log observable; leaving it
+	// registered after we stop draining would eventually fill its buffer and
+	// block Emit for every other log consumer, so always unsubscribe on exit.
+	defer log.UnSubscribe(sub)
+	for {
+		select {
+		case event, ok := <-sub:
+			if !ok {
+				return
+			}
+			if event.LogLevel < log.Level() {
+				continue
+			}
+			trySendLogEvent(logCh, logEvent{level: event.LogLevel.String(), payload: event.Payload})
+		case <-stop:
+			return
+		}
+	}
+}
+
+// trySendLogEvent performs a non-blocking send into logCh, dropping e instead
+// of blocking the log subscriber when a slow Swift consumer has let the
+// buffer fill up.
+func trySendLogEvent(logCh chan<- logEvent, e logEvent) bool {
+	select {
+	case logCh <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+func deliverLogEvents(cb BridgeCallback, logCh <-chan logEvent, stop <-chan struct{}) {
+	for {
+		select {
+		case e := <-logCh:
+			cb.OnLog(e.level, e.payload)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func pumpTrafficAndProxyEvents(cb BridgeCallback, stop <-chan struct{}) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	lastSelected := make(map[string]string)
+	for {
+		select {
+		case <-ticker.C:
+			snapshot := statistic.DefaultManager.Snapshot()
+			cb.OnTraffic(snapshot.UploadTotal, snapshot.DownloadTotal)
+
+			var mem runtime.MemStats
+			runtime.ReadMemStats(&mem)
+			cb.OnMemory(int64(mem.HeapInuse))
+
+			for name, proxy := range tunnel.Proxies() {
+				group, ok := innerAdapter(proxy).(proxyGroupReader)
+				if !ok {
+					continue
+				}
+				now := group.Now()
+				if now == "" || now == lastSelected[name] {
+					continue
+				}
+				lastSelected[name] = now
+				cb.OnProxyChange(name, now)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
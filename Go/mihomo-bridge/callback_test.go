@@ -0,0 +1,23 @@
+// Copyright (c) 2026 Max Lv <max.c.lv@gmail.com>
+//
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+
+package bridge
+
+import "testing"
+
+func TestTrySendLogEvent(t *testing.T) {
+	logCh := make(chan logEvent, 1)
+
+	if !trySendLogEvent(logCh, logEvent{level: "info", payload: "first"}) {
+		t.Fatal("expected send into empty buffer to succeed")
+	}
+	if trySendLogEvent(logCh, logEvent{level: "info", payload: "second"}) {
+		t.Fatal("expected send into full buffer to be dropped, not block/succeed")
+	}
+
+	got := <-logCh
+	if got.payload != "first" {
+		t.Fatalf("buffered event = %q, want %q", got.payload, "first")
+	}
+}
@@ -0,0 +1,402 @@
+// Copyright (c) 2026 Max Lv <max.c.lv@gmail.com>
+//
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/metacubex/mihomo/component/resolver"
+	"github.com/quic-go/quic-go"
+	"gopkg.in/yaml.v3"
+)
+
+// upstreamProbeResult is one entry of the JSON array ProbeUpstreams returns.
+type upstreamProbeResult struct {
+	Target string `json:"target"`
+	Kind   string `json:"kind"`
+	OK     bool   `json:"ok"`
+	RttMs  int64  `json:"rtt_ms"`
+	Error  string `json:"error,omitempty"`
+}
+
+// probeRawConfig captures just the fields of a Mihomo YAML config that name
+// an upstream worth probing; everything else is ignored.
+type probeRawConfig struct {
+	DNS struct {
+		NameServer       []string          `yaml:"nameserver"`
+		Fallback         []string          `yaml:"fallback"`
+		NameServerPolicy map[string]string `yaml:"nameserver-policy"`
+	} `yaml:"dns"`
+	ProxyProviders map[string]struct {
+		URL string `yaml:"url"`
+	} `yaml:"proxy-providers"`
+	RuleProviders map[string]struct {
+		URL string `yaml:"url"`
+	} `yaml:"rule-providers"`
+}
+
+// ProbeUpstreams parses yamlContent the same way ValidateConfig does, then
+// actually dials every DNS nameserver/fallback/nameserver-policy target and
+// every proxy-providers/rule-providers URL it declares, sending a canary A
+// query for domain (DNS targets) or a plain GET (provider URLs). It returns
+// a JSON array of {target, kind, ok, rtt_ms, error} so the iOS UI can show
+// "DoH upstream is blocked in this network" instead of a silent resolver
+// failure after StartProxy.
+func ProbeUpstreams(yamlContent string, domain string, timeoutMs int32) string {
+	if err := ValidateConfig(yamlContent); err != nil {
+		data, _ := json.Marshal([]upstreamProbeResult{{Target: "config", Kind: "yaml", OK: false, Error: err.Error()}})
+		return string(data)
+	}
+
+	var raw probeRawConfig
+	if err := yaml.Unmarshal([]byte(yamlContent), &raw); err != nil {
+		data, _ := json.Marshal([]upstreamProbeResult{{Target: "config", Kind: "yaml", OK: false, Error: err.Error()}})
+		return string(data)
+	}
+	if domain == "" {
+		domain = "www.gstatic.com"
+	}
+
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	targets := collectProbeTargets(raw)
+
+	results := make([]upstreamProbeResult, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		i, target := i, target
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = probeTarget(target, domain, timeout)
+		}()
+	}
+	wg.Wait()
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+type probeTargetSpec struct {
+	value string
+	kind  string
+}
+
+func collectProbeTargets(raw probeRawConfig) []probeTargetSpec {
+	var targets []probeTargetSpec
+	for _, ns := range raw.DNS.NameServer {
+		targets = append(targets, probeTargetSpec{value: ns, kind: dnsUpstreamKind(ns)})
+	}
+	for _, ns := range raw.DNS.Fallback {
+		targets = append(targets, probeTargetSpec{value: ns, kind: dnsUpstreamKind(ns)})
+	}
+	for _, ns := range raw.DNS.NameServerPolicy {
+		targets = append(targets, probeTargetSpec{value: ns, kind: dnsUpstreamKind(ns)})
+	}
+	for _, p := range raw.ProxyProviders {
+		if p.URL != "" {
+			targets = append(targets, probeTargetSpec{value: p.URL, kind: "http"})
+		}
+	}
+	for _, p := range raw.RuleProviders {
+		if p.URL != "" {
+			targets = append(targets, probeTargetSpec{value: p.URL, kind: "http"})
+		}
+	}
+	return targets
+}
+
+// dnsUpstreamKind classifies a nameserver string the way mihomo's own dns
+// config parser does, by URL scheme (falling back to plain UDP).
+func dnsUpstreamKind(ns string) string {
+	switch {
+	case strings.HasPrefix(ns, "tls://"):
+		return "dot"
+	case strings.HasPrefix(ns, "https://"):
+		return "doh"
+	case strings.HasPrefix(ns, "quic://"):
+		return "doq"
+	case strings.HasPrefix(ns, "tcp://"):
+		return "tcp"
+	default:
+		return "udp"
+	}
+}
+
+func probeTarget(target probeTargetSpec, domain string, timeout time.Duration) upstreamProbeResult {
+	result := upstreamProbeResult{Target: target.value, Kind: target.kind}
+	start := time.Now()
+
+	var err error
+	switch target.kind {
+	case "udp", "tcp":
+		err = probeDNSPlain(target, domain, timeout)
+	case "dot":
+		err = probeDNSOverTLS(target, domain, timeout)
+	case "doh":
+		err = probeDNSOverHTTPS(target, domain, timeout)
+	case "doq":
+		err = probeDNSOverQUIC(target, domain, timeout)
+	case "http":
+		err = probeHTTPReachable(target, timeout)
+	default:
+		err = fmt.Errorf("unsupported upstream kind %q", target.kind)
+	}
+
+	result.RttMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.OK = true
+	return result
+}
+
+// resolveUpstreamHost resolves host through mihomo's own resolver.ResolveIP,
+// the same bootstrap path mihomo's outbound dialers use to resolve a proxy
+// server's hostname, rather than falling back to the OS resolver. host is
+// returned unchanged if it is already a literal IP or resolution fails, so
+// callers can always dial the result.
+func resolveUpstreamHost(host string) string {
+	if _, err := netip.ParseAddr(host); err == nil {
+		return host
+	}
+	addr, err := resolver.ResolveIP(host)
+	if err != nil {
+		return host
+	}
+	return addr.String()
+}
+
+// resolvingDialer dials addr after resolving its host through
+// resolveUpstreamHost, preserving the original host/port shape so TLS SNI
+// (set separately via tls.Config.ServerName) still reflects the configured
+// upstream name.
+func resolvingDialer(ctx context.Context, network, addr string, timeout time.Duration) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, ""
+	}
+	resolved := resolveUpstreamHost(host)
+	if port != "" {
+		resolved = net.JoinHostPort(resolved, port)
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	return dialer.DialContext(ctx, network, resolved)
+}
+
+func probeDNSPlain(target probeTargetSpec, domain string, timeout time.Duration) error {
+	addr := strings.TrimPrefix(strings.TrimPrefix(target.value, "udp://"), "tcp://")
+	network := "udp"
+	if target.kind == "tcp" {
+		network = "tcp"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	conn, err := resolvingDialer(ctx, network, addr, timeout)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", addr, err)
+	}
+	defer conn.Close()
+	return exchangeCanaryQuery(conn, domain, timeout)
+}
+
+func probeDNSOverTLS(target probeTargetSpec, domain string, timeout time.Duration) error {
+	addr := strings.TrimPrefix(target.value, "tls://")
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	raw, err := resolvingDialer(ctx, "tcp", addr, timeout)
+	if err != nil {
+		return fmt.Errorf("dial DoT %s: %w", addr, err)
+	}
+	conn := tls.Client(raw, &tls.Config{ServerName: host})
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := conn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return fmt.Errorf("TLS handshake with %s: %w", addr, err)
+	}
+	defer conn.Close()
+	return exchangeCanaryQuery(conn, domain, timeout)
+}
+
+func probeDNSOverHTTPS(target probeTargetSpec, domain string, timeout time.Duration) error {
+	query := buildDNSQuery(domain)
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return resolvingDialer(ctx, network, addr, timeout)
+		},
+	}
+	client := &http.Client{Timeout: timeout, Transport: transport}
+
+	req, err := http.NewRequest(http.MethodPost, target.value, bytes.NewReader(query))
+	if err != nil {
+		return fmt.Errorf("build DoH request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("dial DoH %s: %w", target.value, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("DoH %s returned status %d", target.value, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read DoH response: %w", err)
+	}
+	if !isWellFormedDNSResponse(body) {
+		return fmt.Errorf("DoH %s returned a malformed DNS answer (%d bytes)", target.value, len(body))
+	}
+	return nil
+}
+
+// probeDNSOverQUIC speaks the RFC 9250 DNS-over-QUIC wire format: a single
+// bidirectional stream carrying a 2-byte big-endian length prefix followed
+// by the DNS message, half-closed after the query per the RFC.
+func probeDNSOverQUIC(target probeTargetSpec, domain string, timeout time.Duration) error {
+	addr := strings.TrimPrefix(target.value, "quic://")
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resolvedAddr := addr
+	if h, port, err := net.SplitHostPort(addr); err == nil {
+		resolvedAddr = net.JoinHostPort(resolveUpstreamHost(h), port)
+	}
+
+	tlsConf := &tls.Config{ServerName: host, NextProtos: []string{"doq"}}
+	conn, err := quic.DialAddr(ctx, resolvedAddr, tlsConf, nil)
+	if err != nil {
+		return fmt.Errorf("dial DoQ %s: %w", addr, err)
+	}
+	defer conn.CloseWithError(0, "")
+
+	stream, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		return fmt.Errorf("open DoQ stream: %w", err)
+	}
+	defer stream.Close()
+
+	query := buildDNSQuery(domain)
+	framed := append([]byte{byte(len(query) >> 8), byte(len(query))}, query...)
+	if _, err := stream.Write(framed); err != nil {
+		return fmt.Errorf("write DoQ query: %w", err)
+	}
+	stream.Close()
+
+	stream.SetReadDeadline(time.Now().Add(timeout))
+	lengthBuf := make([]byte, 2)
+	if _, err := io.ReadFull(stream, lengthBuf); err != nil {
+		return fmt.Errorf("read DoQ response length: %w", err)
+	}
+	respLen := int(lengthBuf[0])<<8 | int(lengthBuf[1])
+	resp := make([]byte, respLen)
+	if _, err := io.ReadFull(stream, resp); err != nil {
+		return fmt.Errorf("read DoQ response: %w", err)
+	}
+	if !isWellFormedDNSResponse(resp) {
+		return fmt.Errorf("DoQ %s returned a malformed DNS answer (%d bytes)", addr, len(resp))
+	}
+	return nil
+}
+
+func probeHTTPReachable(target probeTargetSpec, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(target.value)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", target.value, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s returned status %d", target.value, resp.StatusCode)
+	}
+	return nil
+}
+
+// dnsQueryID matches the transaction ID buildDNSQuery hard-codes (0x1234),
+// so isWellFormedDNSResponse can confirm a response actually answers our
+// canary query rather than some unrelated or garbled payload.
+const dnsQueryID = 0x1234
+
+// isWellFormedDNSResponse reports whether resp looks like a genuine answer
+// to our canary query: long enough to contain a header, the QR (response)
+// bit set, and the same transaction ID we sent.
+func isWellFormedDNSResponse(resp []byte) bool {
+	if len(resp) < 12 {
+		return false
+	}
+	id := int(resp[0])<<8 | int(resp[1])
+	qr := resp[2]&0x80 != 0
+	return id == dnsQueryID && qr
+}
+
+// exchangeCanaryQuery sends a DNS A query for domain over conn and confirms
+// a well-formed response comes back, reusing the packet helpers TestDNSResolver
+// already relies on.
+func exchangeCanaryQuery(conn net.Conn, domain string, timeout time.Duration) error {
+	conn.SetDeadline(time.Now().Add(timeout))
+	query := buildDNSQuery(domain)
+	// Over TCP and DoT each DNS message carries a 2-byte big-endian length
+	// prefix; only UDP sends the message bare.
+	_, datagram := conn.(net.PacketConn)
+	if !datagram {
+		query = append([]byte{byte(len(query) >> 8), byte(len(query))}, query...)
+	}
+	if _, err := conn.Write(query); err != nil {
+		return fmt.Errorf("write query: %w", err)
+	}
+	var resp []byte
+	if datagram {
+		buf := make([]byte, 512)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+		resp = buf[:n]
+	} else {
+		lengthBuf := make([]byte, 2)
+		if _, err := io.ReadFull(conn, lengthBuf); err != nil {
+			return fmt.Errorf("read response length: %w", err)
+		}
+		resp = make([]byte, int(lengthBuf[0])<<8|int(lengthBuf[1]))
+		if _, err := io.ReadFull(conn, resp); err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+	}
+	if !isWellFormedDNSResponse(resp) {
+		return fmt.Errorf("malformed response (%d bytes)", len(resp))
+	}
+	return nil
+}
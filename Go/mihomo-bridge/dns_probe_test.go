@@ -0,0 +1,95 @@
+// Copyright (c) 2026 Max Lv <max.c.lv@gmail.com>
+//
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+
+package bridge
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDNSUpstreamKind(t *testing.T) {
+	cases := []struct {
+		ns   string
+		want string
+	}{
+		{"tls://1.1.1.1:853", "dot"},
+		{"https://doh.example.com/dns-query", "doh"},
+		{"quic://dns.example.com:853", "doq"},
+		{"tcp://8.8.8.8:53", "tcp"},
+		{"8.8.8.8:53", "udp"},
+		{"udp://8.8.8.8:53", "udp"},
+	}
+	for _, c := range cases {
+		if got := dnsUpstreamKind(c.ns); got != c.want {
+			t.Errorf("dnsUpstreamKind(%q) = %q, want %q", c.ns, got, c.want)
+		}
+	}
+}
+
+func TestIsWellFormedDNSResponse(t *testing.T) {
+	answer := buildDNSQuery("www.gstatic.com")
+	answer[2] |= 0x80 // set QR bit to mark it as a response
+
+	cases := []struct {
+		name string
+		resp []byte
+		want bool
+	}{
+		{"valid response", answer, true},
+		{"too short", []byte{0x12, 0x34}, false},
+		{"wrong transaction id", append([]byte{0x00, 0x00}, answer[2:]...), false},
+		{"QR bit not set (still a query)", buildDNSQuery("www.gstatic.com"), false},
+	}
+	for _, c := range cases {
+		if got := isWellFormedDNSResponse(c.resp); got != c.want {
+			t.Errorf("isWellFormedDNSResponse(%s) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestProbeTargetRejectsUnsupportedKind(t *testing.T) {
+	result := probeTarget(probeTargetSpec{value: "dhcp://en0", kind: "dhcp"}, "www.gstatic.com", time.Second)
+	if result.OK {
+		t.Fatal("probeTarget with an unrecognized kind should not report ok=true")
+	}
+	if result.Error == "" {
+		t.Fatal("probeTarget with an unrecognized kind should set Error")
+	}
+}
+
+func TestCollectProbeTargets(t *testing.T) {
+	var raw probeRawConfig
+	raw.DNS.NameServer = []string{"tls://1.1.1.1:853"}
+	raw.DNS.Fallback = []string{"8.8.8.8:53"}
+	raw.DNS.NameServerPolicy = map[string]string{"+.example.com": "https://doh.example.com/dns-query"}
+	raw.ProxyProviders = map[string]struct {
+		URL string `yaml:"url"`
+	}{"provider1": {URL: "https://example.com/providers/proxy.yaml"}}
+	raw.RuleProviders = map[string]struct {
+		URL string `yaml:"url"`
+	}{"rule1": {URL: ""}}
+
+	targets := collectProbeTargets(raw)
+
+	want := map[string]string{
+		"tls://1.1.1.1:853":                        "dot",
+		"8.8.8.8:53":                               "udp",
+		"https://doh.example.com/dns-query":        "doh",
+		"https://example.com/providers/proxy.yaml": "http",
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("collectProbeTargets returned %d targets, want %d (empty rule-provider URL must be skipped): %+v", len(targets), len(want), targets)
+	}
+	for _, target := range targets {
+		kind, ok := want[target.value]
+		if !ok {
+			t.Errorf("unexpected target %+v", target)
+			continue
+		}
+		if target.kind != kind {
+			t.Errorf("target %q kind = %q, want %q", target.value, target.kind, kind)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2026 Max Lv <max.c.lv@gmail.com>
+//
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+
+package bridge
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/metacubex/mihomo/component/updater"
+	"github.com/metacubex/mihomo/constant"
+	"github.com/metacubex/mihomo/hub/executor"
+)
+
+// geoUpdaterRegisterOnce guards updater.RegisterGeoUpdater: it installs a
+// process-wide hook, so calling it again on every StartGeoAutoUpdater (e.g.
+// across restarts) would register a duplicate updater rather than replacing
+// the previous one.
+var geoUpdaterRegisterOnce sync.Once
+
+// ErrGeoDatabaseUpdating is returned by UpdateGeoNow when a refresh is
+// already in flight, e.g. because the auto-updater ticker fired again
+// before the previous download finished.
+var ErrGeoDatabaseUpdating = errors.New("geo database update already in progress")
+
+// GeoUpdateCallback is notified as a GEO database refresh progresses.
+// status is one of "updating", "success", or "failed"; errMsg is only
+// populated for "failed".
+type GeoUpdateCallback interface {
+	OnGeoUpdate(status string, errMsg string)
+}
+
+var (
+	geoUpdating   atomic.Bool
+	geoCallbackMu sync.Mutex
+	geoCallback   GeoUpdateCallback
+
+	geoUpdaterMu     sync.Mutex
+	geoUpdaterTicker *time.Ticker
+	geoUpdaterStop   chan struct{}
+)
+
+// SetGeoUpdateCallback registers cb to be notified of GEO database update
+// lifecycle events. Pass nil to unregister.
+func SetGeoUpdateCallback(cb GeoUpdateCallback) {
+	geoCallbackMu.Lock()
+	geoCallback = cb
+	geoCallbackMu.Unlock()
+}
+
+func notifyGeoUpdate(status, errMsg string) {
+	geoCallbackMu.Lock()
+	cb := geoCallback
+	geoCallbackMu.Unlock()
+	if cb != nil {
+		cb.OnGeoUpdate(status, errMsg)
+	}
+}
+
+// StartGeoAutoUpdater starts a background goroutine that refreshes the
+// GeoIP/GeoSite/MMDB/ASN databases in constant.Path.HomeDir() every
+// intervalHours. iOS has no cron or systemd timer to drive this, so the
+// bridge owns the schedule instead of relying on mihomo's own updater loop.
+// Calling this again replaces any previously running ticker.
+func StartGeoAutoUpdater(intervalHours int32) {
+	geoUpdaterMu.Lock()
+	defer geoUpdaterMu.Unlock()
+
+	stopGeoAutoUpdaterLocked()
+
+	if intervalHours <= 0 {
+		intervalHours = 24
+	}
+
+	geoUpdaterRegisterOnce.Do(func() {
+		updater.RegisterGeoUpdater(func() {
+			if err := updateGeoNow(); err != nil {
+				bridgeLog("GEO auto-update failed: %v", err)
+			}
+		})
+	})
+
+	ticker := time.NewTicker(time.Duration(intervalHours) * time.Hour)
+	stop := make(chan struct{})
+	geoUpdaterTicker = ticker
+	geoUpdaterStop = stop
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := updateGeoNow(); err != nil {
+					bridgeLog("GEO auto-update failed: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	bridgeLog("GEO auto-updater started, interval=%dh", intervalHours)
+}
+
+// StopGeoAutoUpdater stops the background GEO database auto-updater, if running.
+func StopGeoAutoUpdater() {
+	geoUpdaterMu.Lock()
+	defer geoUpdaterMu.Unlock()
+	stopGeoAutoUpdaterLocked()
+	bridgeLog("GEO auto-updater stopped")
+}
+
+func stopGeoAutoUpdaterLocked() {
+	if geoUpdaterTicker != nil {
+		geoUpdaterTicker.Stop()
+		geoUpdaterTicker = nil
+	}
+	if geoUpdaterStop != nil {
+		close(geoUpdaterStop)
+		geoUpdaterStop = nil
+	}
+}
+
+// UpdateGeoNow triggers an immediate GEO database refresh, applying the new
+// files only on success and leaving the old ones intact on failure. It
+// returns ErrGeoDatabaseUpdating if a refresh is already underway.
+func UpdateGeoNow() error {
+	return updateGeoNow()
+}
+
+func updateGeoNow() error {
+	if !geoUpdating.CompareAndSwap(false, true) {
+		return ErrGeoDatabaseUpdating
+	}
+	defer geoUpdating.Store(false)
+
+	notifyGeoUpdate("updating", "")
+	bridgeLog("GEO database update starting in %s", constant.Path.HomeDir())
+
+	if err := updater.UpdateGeoDatabases(); err != nil {
+		notifyGeoUpdate("failed", err.Error())
+		bridgeLog("GEO database update failed: %v", err)
+		return fmt.Errorf("update geo databases: %w", err)
+	}
+
+	// Reload the ruleset so the refreshed GeoIP/GeoSite/MMDB/ASN files take
+	// effect immediately. Only reached on success, so a failed download above
+	// never touches the already-running config. mihomo does not expose a
+	// narrower "reload just the rule providers" entry point, so this reuses
+	// the executor.ApplyConfig path StartProxy takes (here without force) — but
+	// config.yaml on disk never contains the TUN fd/FindProcessMode overrides
+	// StartProxy injects at runtime, so those must be re-applied here too, or
+	// the reload would hand the tunnel a config with no file descriptor and
+	// kill routing inside the NE.
+	if IsRunning() {
+		if cfg, err := executor.Parse(); err == nil {
+			applyIOSTunOverrides(cfg, currentTunFd())
+			executor.ApplyConfig(cfg, false)
+		} else {
+			bridgeLog("GEO update: re-parse after refresh failed: %v", err)
+		}
+	}
+
+	notifyGeoUpdate("success", "")
+	bridgeLog("GEO database update completed")
+	return nil
+}
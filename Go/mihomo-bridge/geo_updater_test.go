@@ -0,0 +1,40 @@
+// Copyright (c) 2026 Max Lv <max.c.lv@gmail.com>
+//
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+
+package bridge
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdateGeoNowRejectsConcurrentUpdate(t *testing.T) {
+	if !geoUpdating.CompareAndSwap(false, true) {
+		t.Fatal("test setup: geoUpdating was already true")
+	}
+	defer geoUpdating.Store(false)
+
+	if err := UpdateGeoNow(); !errors.Is(err, ErrGeoDatabaseUpdating) {
+		t.Fatalf("UpdateGeoNow() while updating = %v, want %v", err, ErrGeoDatabaseUpdating)
+	}
+}
+
+func TestSetGeoUpdateCallbackNotifiesStatus(t *testing.T) {
+	var statuses []string
+	SetGeoUpdateCallback(geoUpdateCallbackFunc(func(status, errMsg string) {
+		statuses = append(statuses, status)
+	}))
+	defer SetGeoUpdateCallback(nil)
+
+	notifyGeoUpdate("updating", "")
+	notifyGeoUpdate("failed", "boom")
+
+	if len(statuses) != 2 || statuses[0] != "updating" || statuses[1] != "failed" {
+		t.Fatalf("statuses = %v, want [updating failed]", statuses)
+	}
+}
+
+type geoUpdateCallbackFunc func(status, errMsg string)
+
+func (f geoUpdateCallbackFunc) OnGeoUpdate(status, errMsg string) { f(status, errMsg) }
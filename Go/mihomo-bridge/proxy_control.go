@@ -0,0 +1,174 @@
+// Copyright (c) 2026 Max Lv <max.c.lv@gmail.com>
+//
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/metacubex/mihomo/adapter"
+	"github.com/metacubex/mihomo/adapter/outboundgroup"
+	"github.com/metacubex/mihomo/constant"
+	"github.com/metacubex/mihomo/tunnel"
+)
+
+// innerAdapter unwraps the constant.ProxyAdapter that tunnel.Proxies() wraps
+// in *adapter.Proxy, the same step hub/route takes before type-asserting to
+// an outbound group: the wrapper itself exposes none of the group-specific
+// methods (Now, GetProxies, Set, ...).
+func innerAdapter(proxy constant.Proxy) constant.ProxyAdapter {
+	if wrapped, ok := proxy.(*adapter.Proxy); ok {
+		return wrapped.ProxyAdapter
+	}
+	return proxy
+}
+
+// proxyGroupReader is implemented by outbound groups (Selector, URLTest,
+// Fallback, LoadBalance, ...) that track a currently-selected proxy.
+type proxyGroupReader interface {
+	Now() string
+}
+
+// proxyLister is implemented by outbound groups that expose their member
+// proxies, mirroring outboundgroup.GroupBase.GetProxies.
+type proxyLister interface {
+	GetProxies(touch bool) []constant.Proxy
+}
+
+// proxyGroupInfo mirrors the shape hub/route's GET /proxies/{name} returns,
+// trimmed to what the iOS UI needs for a ping sweep.
+type proxyGroupInfo struct {
+	Name string   `json:"name"`
+	Type string   `json:"type"`
+	Now  string   `json:"now,omitempty"`
+	All  []string `json:"all,omitempty"`
+}
+
+// ListProxyGroups returns every proxy and proxy group known to the running
+// engine as a JSON array, reading directly from tunnel.Proxies() instead of
+// going through the REST controller (see StartWithExternalController).
+func ListProxyGroups() string {
+	proxies := tunnel.Proxies()
+	groups := make([]proxyGroupInfo, 0, len(proxies))
+	for name, proxy := range proxies {
+		info := proxyGroupInfo{Name: name, Type: proxy.Type().String()}
+		inner := innerAdapter(proxy)
+		if group, ok := inner.(proxyGroupReader); ok {
+			info.Now = group.Now()
+		}
+		if lister, ok := inner.(proxyLister); ok {
+			for _, p := range lister.GetProxies(false) {
+				info.All = append(info.All, p.Name())
+			}
+		}
+		groups = append(groups, info)
+	}
+	data, err := json.Marshal(groups)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+// SelectProxy switches group to the node named name, the same operation
+// hub/route performs on PUT /proxies/{group}. Only Selector groups support
+// switching; the rest (URLTest, Fallback, LoadBalance, ...) pick their own
+// active node automatically.
+func SelectProxy(group, name string) error {
+	proxy, ok := tunnel.Proxies()[group]
+	if !ok {
+		return fmt.Errorf("proxy group %q not found", group)
+	}
+	selector, ok := innerAdapter(proxy).(*outboundgroup.Selector)
+	if !ok {
+		return fmt.Errorf("proxy group %q does not support selection", group)
+	}
+	if err := selector.Set(name); err != nil {
+		return fmt.Errorf("select %s in %s: %w", name, group, err)
+	}
+	bridgeLog("SelectProxy: %s -> %s", group, name)
+	return nil
+}
+
+// CurrentSelected returns the currently selected node name for group, or
+// the empty string if group is unknown or does not track a selection.
+func CurrentSelected(group string) string {
+	proxy, ok := tunnel.Proxies()[group]
+	if !ok {
+		return ""
+	}
+	if g, ok := innerAdapter(proxy).(proxyGroupReader); ok {
+		return g.Now()
+	}
+	return ""
+}
+
+type latencyResult struct {
+	DelayMs int    `json:"delay_ms"`
+	Error   string `json:"error,omitempty"`
+}
+
+const latencyWorkerPoolSize = 4
+
+// TestGroupLatency URL-tests every node in group concurrently, bounded to
+// latencyWorkerPoolSize in flight at once, and returns a JSON object mapping
+// node name to {delay_ms, error} so the UI can render a ping sweep for the
+// whole group in one call.
+func TestGroupLatency(group, testURL string, timeoutMs int32) string {
+	proxy, ok := tunnel.Proxies()[group]
+	if !ok {
+		data, _ := json.Marshal(map[string]latencyResult{})
+		return string(data)
+	}
+	lister, ok := innerAdapter(proxy).(proxyLister)
+	if !ok {
+		data, _ := json.Marshal(map[string]latencyResult{})
+		return string(data)
+	}
+
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	nodes := lister.GetProxies(false)
+	results := make(map[string]latencyResult, len(nodes))
+	var resultsMu sync.Mutex
+
+	sem := make(chan struct{}, latencyWorkerPoolSize)
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		node := node
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			delay, err := node.URLTest(ctx, testURL, nil)
+			res := latencyResult{DelayMs: int(delay)}
+			if err != nil {
+				res.Error = err.Error()
+			}
+
+			resultsMu.Lock()
+			results[node.Name()] = res
+			resultsMu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2026 Max Lv <max.c.lv@gmail.com>
+//
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+
+package bridge
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestProxyGroupInfoJSON(t *testing.T) {
+	info := proxyGroupInfo{Name: "Proxy", Type: "Selector", Now: "HK-01", All: []string{"HK-01", "US-01"}}
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded proxyGroupInfo
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, info) {
+		t.Fatalf("round-tripped %+v, want %+v", decoded, info)
+	}
+
+	empty := proxyGroupInfo{Name: "DIRECT", Type: "Direct"}
+	data, err = json.Marshal(empty)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	const wantEmpty = `{"name":"DIRECT","type":"Direct"}`
+	if string(data) != wantEmpty {
+		t.Fatalf("Marshal(empty) = %s, want %s (now/all must omit when unset)", data, wantEmpty)
+	}
+}
+
+func TestLatencyResultJSON(t *testing.T) {
+	ok := latencyResult{DelayMs: 120}
+	data, err := json.Marshal(ok)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{"delay_ms":120}` {
+		t.Fatalf("Marshal(ok) = %s, want delay_ms only with error omitted", data)
+	}
+
+	failed := latencyResult{Error: "timeout"}
+	data, err = json.Marshal(failed)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{"delay_ms":0,"error":"timeout"}` {
+		t.Fatalf("Marshal(failed) = %s, want delay_ms=0 and error set", data)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2026 Max Lv <max.c.lv@gmail.com>
+//
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+
+package bridge
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	netURL "net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/metacubex/mihomo/config"
+	"github.com/metacubex/mihomo/constant"
+)
+
+// SubscriptionResult describes the outcome of a FetchSubscription call.
+type SubscriptionResult struct {
+	TrafficUsed  int64
+	TrafficTotal int64
+	ExpireUnix   int64
+	ProfileName  string
+	ETag         string
+	NotModified  bool
+}
+
+type subscriptionCacheEntry struct {
+	etag         string
+	lastModified string
+}
+
+var (
+	subscriptionMu        sync.Mutex
+	subscriptionProxyAddr string
+
+	subscriptionCacheMu sync.Mutex
+	subscriptionCache   = make(map[string]subscriptionCacheEntry)
+)
+
+// SetSubscriptionProxy routes subsequent FetchSubscription requests through
+// addr (e.g. "127.0.0.1:7890", the already-running mixed proxy) instead of
+// dialing directly, so a subscription URL censored on the current network
+// can still be reached once the proxy itself is up.
+func SetSubscriptionProxy(addr string) {
+	subscriptionMu.Lock()
+	subscriptionProxyAddr = addr
+	subscriptionMu.Unlock()
+}
+
+// FetchSubscription downloads a Clash/Mihomo YAML subscription from subURL,
+// validates it via config.Parse, and atomically writes it to
+// <homeDir>/config.yaml via a temp-file + rename. If the server honors the
+// cached ETag/Last-Modified with a 304, the existing config.yaml is left
+// untouched and NotModified is set.
+func FetchSubscription(subURL, userAgent, etag string) (SubscriptionResult, error) {
+	var result SubscriptionResult
+
+	req, err := http.NewRequest(http.MethodGet, subURL, nil)
+	if err != nil {
+		return result, fmt.Errorf("build request: %w", err)
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	req.Header.Set("Accept-Encoding", "gzip, br")
+
+	subscriptionCacheMu.Lock()
+	cached, hasCache := subscriptionCache[subURL]
+	subscriptionCacheMu.Unlock()
+	if etag == "" && hasCache {
+		etag = cached.etag
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if hasCache && cached.lastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.lastModified)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	if proxyAddr := currentSubscriptionProxy(); proxyAddr != "" {
+		proxyURL := &netURL.URL{Scheme: "http", Host: proxyAddr}
+		client.Transport = &http.Transport{Proxy: http.ProxyURL(proxyURL)}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("fetch subscription: %w", err)
+	}
+	defer resp.Body.Close()
+
+	result.ETag = resp.Header.Get("ETag")
+
+	if resp.StatusCode == http.StatusNotModified {
+		result.NotModified = true
+		if result.ETag == "" {
+			result.ETag = etag
+		}
+		return result, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("fetch subscription: unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := decodeBody(resp)
+	if err != nil {
+		return result, fmt.Errorf("decode subscription body: %w", err)
+	}
+
+	parseSubscriptionUserInfo(resp.Header.Get("subscription-userinfo"), &result)
+	result.ProfileName = subscriptionProfileName(resp, subURL)
+
+	if _, err := config.Parse(body); err != nil {
+		return result, fmt.Errorf("parse subscription config: %w", err)
+	}
+
+	homeDir := constant.Path.HomeDir()
+	configPath := filepath.Join(homeDir, "config.yaml")
+	if err := writeFileAtomic(configPath, body); err != nil {
+		return result, fmt.Errorf("write config: %w", err)
+	}
+
+	subscriptionCacheMu.Lock()
+	subscriptionCache[subURL] = subscriptionCacheEntry{
+		etag:         result.ETag,
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+	subscriptionCacheMu.Unlock()
+
+	bridgeLog("Subscription fetched from %s (%d bytes)", subURL, len(body))
+	return result, nil
+}
+
+func currentSubscriptionProxy() string {
+	subscriptionMu.Lock()
+	defer subscriptionMu.Unlock()
+	return subscriptionProxyAddr
+}
+
+func decodeBody(resp *http.Response) ([]byte, error) {
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "gzip":
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return io.ReadAll(gz)
+	case "br":
+		return io.ReadAll(brotli.NewReader(resp.Body))
+	default:
+		return io.ReadAll(resp.Body)
+	}
+}
+
+// parseSubscriptionUserInfo parses the Clash-style subscription-userinfo
+// header, e.g. "upload=123; download=456; total=789; expire=1735689600".
+func parseSubscriptionUserInfo(header string, result *SubscriptionResult) {
+	if header == "" {
+		return
+	}
+	var upload, download int64
+	for _, field := range strings.Split(header, ";") {
+		field = strings.TrimSpace(field)
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "upload":
+			upload = value
+		case "download":
+			download = value
+		case "total":
+			result.TrafficTotal = value
+		case "expire":
+			result.ExpireUnix = value
+		}
+	}
+	result.TrafficUsed = upload + download
+}
+
+func subscriptionProfileName(resp *http.Response, subURL string) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := params["filename"]; name != "" {
+			return name
+		}
+	}
+	if parsed, err := netURL.Parse(subURL); err == nil {
+		if base := filepath.Base(parsed.Path); base != "." && base != "/" {
+			return base
+		}
+		return parsed.Host
+	}
+	return ""
+}
+
+// writeFileAtomic writes data to path via a temp file in the same directory
+// followed by a rename, so a crash mid-write never leaves a truncated
+// config.yaml behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	// os.CreateTemp creates the file at mode 0600; match SetConfig's 0644 so
+	// a subscription-written config.yaml isn't silently less readable than
+	// one written directly by Swift.
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
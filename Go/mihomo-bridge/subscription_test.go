@@ -0,0 +1,82 @@
+// Copyright (c) 2026 Max Lv <max.c.lv@gmail.com>
+//
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+
+package bridge
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSubscriptionUserInfo(t *testing.T) {
+	var result SubscriptionResult
+	parseSubscriptionUserInfo("upload=1024; download=2048; total=1073741824; expire=1735689600", &result)
+
+	if result.TrafficUsed != 1024+2048 {
+		t.Fatalf("TrafficUsed = %d, want %d", result.TrafficUsed, 1024+2048)
+	}
+	if result.TrafficTotal != 1073741824 {
+		t.Fatalf("TrafficTotal = %d, want %d", result.TrafficTotal, 1073741824)
+	}
+	if result.ExpireUnix != 1735689600 {
+		t.Fatalf("ExpireUnix = %d, want %d", result.ExpireUnix, 1735689600)
+	}
+}
+
+func TestParseSubscriptionUserInfoEmpty(t *testing.T) {
+	var result SubscriptionResult
+	parseSubscriptionUserInfo("", &result)
+	if result != (SubscriptionResult{}) {
+		t.Fatalf("expected zero value for empty header, got %+v", result)
+	}
+}
+
+func TestSubscriptionProfileName(t *testing.T) {
+	withDisposition := &http.Response{Header: http.Header{
+		"Content-Disposition": []string{`attachment; filename="my-profile.yaml"`},
+	}}
+	if name := subscriptionProfileName(withDisposition, "https://example.com/sub"); name != "my-profile.yaml" {
+		t.Fatalf("ProfileName = %q, want %q", name, "my-profile.yaml")
+	}
+
+	withoutDisposition := &http.Response{Header: http.Header{}}
+	if name := subscriptionProfileName(withoutDisposition, "https://example.com/sub/profile.yaml?token=abc"); name != "profile.yaml" {
+		t.Fatalf("ProfileName = %q, want %q", name, "profile.yaml")
+	}
+}
+
+func TestWriteFileAtomicPermissions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	if err := writeFileAtomic(path, []byte("proxies: []\n")); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0644 {
+		t.Fatalf("config.yaml mode = %v, want 0644 to match SetConfig", perm)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "proxies: []\n" {
+		t.Fatalf("content = %q, want %q", data, "proxies: []\n")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected the temp file to be renamed away, found %d entries", len(entries))
+	}
+}